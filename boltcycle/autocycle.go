@@ -0,0 +1,56 @@
+package boltcycle
+
+import "time"
+
+// WithAutoCycle starts a goroutine that calls CycleNodes every interval, so the pseudo-LRU cycles
+// on its own instead of depending on the embedder to schedule it.  The goroutine is joined by
+// Close the same way the read-movement goroutine is.
+func WithAutoCycle(interval time.Duration) DBConfiguration {
+	return func(c *CycleDB) error {
+		c.autoCycleInterval = interval
+		return nil
+	}
+}
+
+// WithTTL sizes minNumOldBuckets to ceil(d/interval), using the interval from WithAutoCycle, so that
+// written data that is not read again survives at least d and is evicted no later than
+// (ceil(d/interval)+1)*interval after it was written, which is d+interval when d is a multiple of
+// interval.  It requires
+// WithAutoCycle to also be passed to New/NewWithEngine, since the guarantee depends on how often
+// buckets actually cycle.
+func WithTTL(d time.Duration) DBConfiguration {
+	return func(c *CycleDB) error {
+		c.ttl = d
+		return nil
+	}
+}
+
+// ceilDivDuration returns the number of interval-sized buckets needed to cover at least d.
+func ceilDivDuration(d, interval time.Duration) int {
+	n := int(d / interval)
+	if d%interval != 0 {
+		n++
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// autoCycleLoop calls CycleNodes on a timer until Close closes cycleStop.
+func (c *CycleDB) autoCycleLoop() {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.autoCycleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.CycleNodes(); err != nil && c.asyncErrors != nil {
+				c.asyncErrors <- err
+			}
+			c.nextCycleAt.Store(time.Now().Add(c.autoCycleInterval))
+		case <-c.cycleStop:
+			return
+		}
+	}
+}
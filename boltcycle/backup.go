@@ -0,0 +1,133 @@
+package boltcycle
+
+import (
+	"io"
+	"os"
+
+	bbolt "go.etcd.io/bbolt"
+)
+
+// restoringSuffix names the temporary top level bucket Restore builds a new bucket ring in before
+// swapping it in for the live one.
+var restoringSuffix = []byte("-restoring")
+
+// Backup writes a hot, consistent file-level snapshot of the entire underlying database to w,
+// built on bbolt's Tx.WriteTo.  It requires a bbolt backed Engine; use Snapshot for a logical,
+// engine independent format.
+func (c *CycleDB) Backup(w io.Writer) (int64, error) {
+	be, ok := c.engine.(*bboltEngine)
+	if !ok {
+		return 0, errEngineNotBbolt
+	}
+	var written int64
+	err := be.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	return written, err
+}
+
+// Restore replaces the current bucket ring with the contents of a backup produced by Backup.  It
+// reads r into a temporary bbolt file, copies that file's bucket ring into a temporary bucket of
+// the live database, runs VerifyBackup against it, and only then -- in one more bbolt transaction
+// -- replaces the canonical bucket with the verified copy and drops the temporary one.  The
+// temporary bucket exists so a restore that fails partway through never touches the live ring; the
+// canonical bucket name never changes, so there is nothing to persist beyond the data itself.
+func (c *CycleDB) Restore(r io.Reader) error {
+	be, ok := c.engine.(*bboltEngine)
+	if !ok {
+		return errEngineNotBbolt
+	}
+
+	tmpFile, err := os.CreateTemp("", "boltcycle-restore-*.db")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	restoredDB, err := bbolt.Open(tmpPath, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer restoredDB.Close()
+
+	oldName := c.timesInBucketName()
+	tempName := append(append([]byte{}, oldName...), restoringSuffix...)
+
+	err = be.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(tempName); err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+		newRoot, err := tx.CreateBucket(tempName)
+		if err != nil {
+			return err
+		}
+		return restoredDB.View(func(rtx *bbolt.Tx) error {
+			srcRoot := rtx.Bucket(oldName)
+			if srcRoot == nil {
+				return errUnableToFindRootBucket
+			}
+			return copyBucketTree(srcRoot, newRoot)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.VerifyBackup(tempName); err != nil {
+		_ = be.db.Update(func(tx *bbolt.Tx) error {
+			return tx.DeleteBucket(tempName)
+		})
+		return err
+	}
+
+	return be.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.DeleteBucket(oldName); err != nil && err != bbolt.ErrBucketNotFound {
+			return err
+		}
+		newRoot, err := tx.CreateBucket(oldName)
+		if err != nil {
+			return err
+		}
+		if err := copyBucketTree(tx.Bucket(tempName), newRoot); err != nil {
+			return err
+		}
+		return tx.DeleteBucket(tempName)
+	})
+}
+
+// VerifyBackup checks that bucketName, rather than the currently active bucket ring, has the
+// structure CycleDB expects at its top level: every child is a nested time bucket with an 8 byte
+// name.  The contents of the time buckets are not inspected.  Restore calls this against the temporary ring it builds, before committing it over the
+// canonical bucket.
+//
+// It deliberately does not call VerifyCompressed: a key living in more than one time bucket is the
+// normal pseudo-LRU state of a cycle before recopy compaction runs, not a sign of a bad backup.
+func (c *CycleDB) VerifyBackup(bucketName []byte) error {
+	return c.verifyBucketsNamed(bucketName)
+}
+
+// copyBucketTree recursively copies every key/value and nested bucket from src into dst.
+func copyBucketTree(src, dst *bbolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+		srcChild := src.Bucket(k)
+		dstChild, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucketTree(srcChild, dstChild)
+	})
+}
@@ -0,0 +1,53 @@
+package boltcycle
+
+// ValueCodec transforms values on their way into and out of the database, e.g. to compress them.
+// Decode must be the exact inverse of Encode.
+type ValueCodec interface {
+	// ID is the one byte format prefix this codec stamps on every value it encodes, so Read can
+	// tell which codec to decode a value with.  It must be unique among codecs ever configured on
+	// a given database.
+	ID() byte
+
+	// Encode transforms value for storage.
+	Encode(value []byte) []byte
+
+	// Decode reverses Encode.
+	Decode(value []byte) ([]byte, error)
+}
+
+// WithValueCodec configures a ValueCodec that Write/BatchWrite encode values through and Read
+// decodes them back out of.  Without this option CycleDB stores values exactly as given, matching
+// its behavior before ValueCodec existed.  Once configured, every newly written value is stamped
+// with the codec's one byte ID; values already in the database whose first byte is not that ID --
+// values that predate the codec, or that carry a different codec's ID -- are returned as-is.  A
+// value written before the codec was configured whose first byte happens to equal the codec's ID
+// cannot be told apart from an encoded one and is passed to Decode.  Use Recompress to rewrite
+// existing values under the new codec if you want them compressed too.
+func WithValueCodec(codec ValueCodec) DBConfiguration {
+	return func(c *CycleDB) error {
+		c.codec = codec
+		return nil
+	}
+}
+
+// encodeValue applies the configured codec to value, prefixing the result with the codec's ID.
+// With no codec configured it returns value unchanged.
+func (c *CycleDB) encodeValue(value []byte) []byte {
+	if c.codec == nil {
+		return value
+	}
+	encoded := c.codec.Encode(value)
+	out := make([]byte, 0, len(encoded)+1)
+	out = append(out, c.codec.ID())
+	return append(out, encoded...)
+}
+
+// decodeValue reverses encodeValue.  With no codec configured, or when the value's first byte is
+// not the configured codec's ID, the value is returned as-is; otherwise the rest of the value is
+// passed to the codec's Decode.
+func (c *CycleDB) decodeValue(value []byte) ([]byte, error) {
+	if c.codec == nil || len(value) == 0 || value[0] != c.codec.ID() {
+		return value, nil
+	}
+	return c.codec.Decode(value[1:])
+}
@@ -0,0 +1,95 @@
+package boltcycle
+
+// Engine is the minimal storage interface CycleDB needs from an underlying database.  It exposes
+// just enough to support the cycle-of-buckets algorithm: a view/update transaction, bucket style
+// namespaces nested one level deep (the root bucket of time buckets, each holding keys written
+// during that cycle), ordered cursor iteration, and atomic per-key delete.
+//
+// New wraps a *bbolt.DB in an Engine.  NewWithEngine accepts any Engine, which lets callers pick a
+// backend better suited to their workload (bbolt, badger, or an in-memory engine for tests).
+type Engine interface {
+	// View runs fn in a read-only transaction.  fn must not retain any []byte it reads past the
+	// lifetime of the transaction.
+	View(fn func(tx Tx) error) error
+
+	// Update runs fn in a read-write transaction.  The transaction commits if fn returns nil and
+	// rolls back otherwise.
+	Update(fn func(tx Tx) error) error
+
+	// IsReadOnly returns true if the engine was opened read-only, in which case CycleDB disables
+	// its background read-movement goroutine.
+	IsReadOnly() bool
+}
+
+// Batcher is implemented by engines that can coalesce many small write transactions submitted by
+// concurrent callers into one, trading a little added per-call latency for much higher aggregate
+// throughput.  bboltEngine implements it on top of bbolt's DB.Batch; engines without a native
+// equivalent simply don't implement it, and CycleDB.BatchWrite returns an error on them; Write,
+// which uses Engine.Update, works on every engine.
+type Batcher interface {
+	// Batch behaves like Update, except the engine may run fn as part of a larger transaction
+	// shared with other concurrent Batch callers.  fn may therefore be called more than once if
+	// the shared transaction needs to be retried.
+	Batch(fn func(tx Tx) error) error
+}
+
+// Tx is a single view or update transaction against an Engine.
+type Tx interface {
+	// Bucket returns the named top level bucket, or nil if it does not exist.
+	Bucket(name []byte) Bucket
+
+	// CreateBucketIfNotExists returns the named top level bucket, creating it if necessary.  It
+	// must only be called inside an Update transaction.
+	CreateBucketIfNotExists(name []byte) (Bucket, error)
+}
+
+// Bucket is a namespace of key/value pairs, or of nested buckets.  CycleDB never nests buckets
+// more than one level deep: a root bucket of time buckets, each of which holds real key/value
+// data.
+type Bucket interface {
+	// Bucket returns the named nested bucket, or nil if it does not exist.
+	Bucket(name []byte) Bucket
+
+	// CreateBucket creates and returns a new nested bucket.  It is an error to create a bucket
+	// that already exists.
+	CreateBucket(name []byte) (Bucket, error)
+
+	// DeleteBucket atomically removes a nested bucket and everything inside it.
+	DeleteBucket(name []byte) error
+
+	// ForEach calls fn for every key in the bucket, in ascending key order.  If the bucket holds
+	// nested buckets rather than values, v is nil.
+	ForEach(fn func(k, v []byte) error) error
+
+	// Put atomically writes a key/value pair into the bucket.
+	Put(key, value []byte) error
+
+	// Cursor returns a Cursor positioned before the first key of the bucket.
+	Cursor() Cursor
+}
+
+// Cursor iterates the keys of a Bucket in sorted order.  It is only valid for the lifetime of the
+// transaction that created it.
+type Cursor interface {
+	// First moves the cursor to the first key and returns it, or (nil, nil) if the bucket is
+	// empty.
+	First() (key, value []byte)
+
+	// Last moves the cursor to the last key and returns it, or (nil, nil) if the bucket is empty.
+	Last() (key, value []byte)
+
+	// Next moves the cursor to the next key and returns it, or (nil, nil) if there is no next key.
+	Next() (key, value []byte)
+
+	// Prev moves the cursor to the previous key and returns it, or (nil, nil) if there is no
+	// previous key.
+	Prev() (key, value []byte)
+
+	// Seek moves the cursor to the first key greater than or equal to seek and returns it, or
+	// (nil, nil) if there is no such key.
+	Seek(seek []byte) (key, value []byte)
+
+	// Delete atomically removes the key/value pair the cursor is currently positioned on.  It
+	// must only be called inside an Update transaction.
+	Delete() error
+}
@@ -0,0 +1,254 @@
+package boltcycle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	badger "github.com/dgraph-io/badger"
+)
+
+// badgerEngine adapts a *badger.DB to the Engine interface.  Badger is an LSM tree, so unlike
+// bolt/bbolt it has no native concept of nested buckets; a bucket is emulated as a key prefix, and
+// a bucket's existence is recorded with a marker key so ForEach/Cursor can enumerate children.
+// This trades bolt's mmap and single-writer cost for badger's append-only write path, which suits
+// workloads dominated by small concurrent writes.
+type badgerEngine struct {
+	db *badger.DB
+}
+
+// NewBadgerEngine wraps db as an Engine for use with NewWithEngine.
+func NewBadgerEngine(db *badger.DB) Engine {
+	return &badgerEngine{db: db}
+}
+
+func (e *badgerEngine) View(fn func(tx Tx) error) error {
+	return e.db.View(func(txn *badger.Txn) error {
+		return fn(&badgerTx{txn: txn})
+	})
+}
+
+func (e *badgerEngine) Update(fn func(tx Tx) error) error {
+	return e.db.Update(func(txn *badger.Txn) error {
+		return fn(&badgerTx{txn: txn})
+	})
+}
+
+func (e *badgerEngine) IsReadOnly() bool {
+	return false
+}
+
+// Marker bytes distinguish a child bucket's marker entry (no value, just existence) from a real
+// key/value entry living directly under a bucket's prefix.  Neither appears in caller supplied
+// keys because every real key is written under a bucket prefix ending in dataMarker.
+const (
+	badgerBucketMarker byte = 0xfe
+	badgerDataMarker   byte = 0xff
+)
+
+func encodeBadgerSegment(name []byte) []byte {
+	var lenBytes [4]byte
+	binary.BigEndian.PutUint32(lenBytes[:], uint32(len(name)))
+	out := make([]byte, 0, 4+len(name))
+	out = append(out, lenBytes[:]...)
+	return append(out, name...)
+}
+
+type badgerTx struct {
+	txn *badger.Txn
+}
+
+func (t *badgerTx) Bucket(name []byte) Bucket {
+	return badgerBucketIfExists(t.txn, nil, name)
+}
+
+func (t *badgerTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	return badgerCreateBucketIfNotExists(t.txn, nil, name)
+}
+
+type badgerBucket struct {
+	txn    *badger.Txn
+	prefix []byte
+}
+
+// badgerBucketIfExists returns the child bucket named name under parentPrefix, or nil if its
+// marker key is absent.
+func badgerBucketIfExists(txn *badger.Txn, parentPrefix, name []byte) Bucket {
+	markerKey := append(append([]byte{}, parentPrefix...), badgerBucketMarker)
+	markerKey = append(markerKey, encodeBadgerSegment(name)...)
+	if _, err := txn.Get(markerKey); err != nil {
+		return nil
+	}
+	return &badgerBucket{txn: txn, prefix: append(append([]byte{}, parentPrefix...), encodeBadgerSegment(name)...)}
+}
+
+func badgerCreateBucketIfNotExists(txn *badger.Txn, parentPrefix, name []byte) (Bucket, error) {
+	markerKey := append(append([]byte{}, parentPrefix...), badgerBucketMarker)
+	markerKey = append(markerKey, encodeBadgerSegment(name)...)
+	if _, err := txn.Get(markerKey); err != nil {
+		if err := txn.Set(markerKey, []byte{}); err != nil {
+			return nil, err
+		}
+	}
+	return &badgerBucket{txn: txn, prefix: append(append([]byte{}, parentPrefix...), encodeBadgerSegment(name)...)}, nil
+}
+
+func (b *badgerBucket) Bucket(name []byte) Bucket {
+	return badgerBucketIfExists(b.txn, b.prefix, name)
+}
+
+func (b *badgerBucket) CreateBucket(name []byte) (Bucket, error) {
+	if badgerBucketIfExists(b.txn, b.prefix, name) != nil {
+		return nil, errBucketExists
+	}
+	return badgerCreateBucketIfNotExists(b.txn, b.prefix, name)
+}
+
+func (b *badgerBucket) DeleteBucket(name []byte) error {
+	childPrefix := append(append([]byte{}, b.prefix...), encodeBadgerSegment(name)...)
+	markerKey := append(append([]byte{}, b.prefix...), badgerBucketMarker)
+	markerKey = append(markerKey, encodeBadgerSegment(name)...)
+	if err := b.txn.Delete(markerKey); err != nil {
+		return err
+	}
+	it := b.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+	var toDelete [][]byte
+	for it.Seek(childPrefix); it.ValidForPrefix(childPrefix); it.Next() {
+		toDelete = append(toDelete, it.Item().KeyCopy(nil))
+	}
+	for _, k := range toDelete {
+		if err := b.txn.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataPrefix is the prefix under which real key/value entries of this bucket live, as opposed to
+// the marker entries of its child buckets.
+func (b *badgerBucket) dataPrefix() []byte {
+	return append(append([]byte{}, b.prefix...), badgerDataMarker)
+}
+
+// ForEach collects its entries up front rather than calling fn while its iterator is still open:
+// badger allows only one iterator at a time inside an Update transaction, and fn routinely opens
+// its own (e.g. Delete and Recompress both open a Cursor on a child bucket from inside ForEach).
+func (b *badgerBucket) ForEach(fn func(k, v []byte) error) error {
+	for _, entry := range collectBadgerEntries(b) {
+		if err := fn(entry.key, entry.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *badgerBucket) Put(key, value []byte) error {
+	return b.txn.Set(append(append([]byte{}, b.dataPrefix()...), key...), value)
+}
+
+// Cursor returns a Cursor over this bucket's children, in ascending order by decoded name.
+// Bolt keeps nested buckets and direct values in the same page-level keyspace, so its Cursor
+// walks both together; badger has no such keyspace, so badgerCursor materializes both the bucket
+// markers (as name/nil pairs, like bolt) and the real key/value entries up front and merges them
+// by name, the same way memCursor snapshots a bucket's keys at Cursor() time.
+func (b *badgerBucket) Cursor() Cursor {
+	return &badgerCursor{bucket: b, entries: collectBadgerEntries(b), pos: -1}
+}
+
+type badgerEntry struct {
+	key      []byte
+	value    []byte
+	isBucket bool
+}
+
+func collectBadgerEntries(b *badgerBucket) []badgerEntry {
+	var entries []badgerEntry
+
+	it := b.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	bucketPrefix := append(append([]byte{}, b.prefix...), badgerBucketMarker)
+	for it.Seek(bucketPrefix); it.ValidForPrefix(bucketPrefix); it.Next() {
+		name, _ := decodeBadgerSegment(it.Item().Key()[len(bucketPrefix):])
+		entries = append(entries, badgerEntry{key: append([]byte{}, name...), isBucket: true})
+	}
+
+	dataPrefix := b.dataPrefix()
+	for it.Seek(dataPrefix); it.ValidForPrefix(dataPrefix); it.Next() {
+		key := append([]byte{}, it.Item().Key()[len(dataPrefix):]...)
+		value, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, badgerEntry{key: key, value: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	return entries
+}
+
+func decodeBadgerSegment(encoded []byte) ([]byte, []byte) {
+	if len(encoded) < 4 {
+		return nil, nil
+	}
+	n := binary.BigEndian.Uint32(encoded[:4])
+	return encoded[4 : 4+n], encoded[4+n:]
+}
+
+// badgerCursor walks a snapshot of a bucket's children -- nested bucket markers and real
+// key/value entries alike -- taken when Cursor was called.
+type badgerCursor struct {
+	bucket  *badgerBucket
+	entries []badgerEntry
+	pos     int
+}
+
+func (c *badgerCursor) at(pos int) ([]byte, []byte) {
+	if pos < 0 || pos >= len(c.entries) {
+		return nil, nil
+	}
+	c.pos = pos
+	return c.entries[pos].key, c.entries[pos].value
+}
+
+func (c *badgerCursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+func (c *badgerCursor) Last() ([]byte, []byte) {
+	return c.at(len(c.entries) - 1)
+}
+
+func (c *badgerCursor) Next() ([]byte, []byte) {
+	return c.at(c.pos + 1)
+}
+
+func (c *badgerCursor) Prev() ([]byte, []byte) {
+	return c.at(c.pos - 1)
+}
+
+func (c *badgerCursor) Seek(seek []byte) ([]byte, []byte) {
+	idx := sort.Search(len(c.entries), func(i int) bool {
+		return bytes.Compare(c.entries[i].key, seek) >= 0
+	})
+	return c.at(idx)
+}
+
+func (c *badgerCursor) Delete() error {
+	if c.pos < 0 || c.pos >= len(c.entries) {
+		return nil
+	}
+	entry := c.entries[c.pos]
+	if entry.isBucket {
+		full := append(append([]byte{}, c.bucket.prefix...), badgerBucketMarker)
+		full = append(full, encodeBadgerSegment(entry.key)...)
+		return c.bucket.txn.Delete(full)
+	}
+	full := append(append([]byte{}, c.bucket.dataPrefix()...), entry.key...)
+	return c.bucket.txn.Delete(full)
+}
+
+var _ Engine = &badgerEngine{}
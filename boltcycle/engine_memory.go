@@ -0,0 +1,200 @@
+package boltcycle
+
+import (
+	"sort"
+	"sync"
+)
+
+// memEngine is an in-memory Engine, useful for tests and for callers that want CycleDB's pseudo-
+// LRU behavior without any durability guarantees.  A single RWMutex stands in for bolt's
+// transaction isolation: View takes a read lock, Update takes a write lock and clones root first
+// so it can restore the pre-transaction state if fn returns an error, same as Engine.Update
+// promises.
+type memEngine struct {
+	mu   sync.RWMutex
+	root *memBucket
+}
+
+// NewMemEngine returns an Engine backed entirely by memory, for use with NewWithEngine.
+func NewMemEngine() Engine {
+	return &memEngine{root: newMemBucket()}
+}
+
+func (e *memEngine) View(fn func(tx Tx) error) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return fn(&memTx{root: e.root})
+}
+
+func (e *memEngine) Update(fn func(tx Tx) error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	before := e.root.clone()
+	if err := fn(&memTx{root: e.root}); err != nil {
+		e.root = before
+		return err
+	}
+	return nil
+}
+
+func (e *memEngine) IsReadOnly() bool {
+	return false
+}
+
+type memTx struct {
+	root *memBucket
+}
+
+func (t *memTx) Bucket(name []byte) Bucket {
+	return t.root.Bucket(name)
+}
+
+func (t *memTx) CreateBucketIfNotExists(name []byte) (Bucket, error) {
+	if b := t.root.Bucket(name); b != nil {
+		return b, nil
+	}
+	return t.root.CreateBucket(name)
+}
+
+// memBucket is either a namespace of nested buckets or a namespace of key/value pairs; CycleDB
+// never mixes the two within one bucket, so there is no need to track which mode it is in.
+type memBucket struct {
+	buckets map[string]*memBucket
+	values  map[string][]byte
+}
+
+func newMemBucket() *memBucket {
+	return &memBucket{
+		buckets: make(map[string]*memBucket),
+		values:  make(map[string][]byte),
+	}
+}
+
+// clone deep copies b, so memEngine.Update can restore the pre-transaction state if fn returns an
+// error, matching Engine.Update's all-or-nothing contract.
+func (b *memBucket) clone() *memBucket {
+	cp := newMemBucket()
+	for name, child := range b.buckets {
+		cp.buckets[name] = child.clone()
+	}
+	for key, value := range b.values {
+		cp.values[key] = append([]byte{}, value...)
+	}
+	return cp
+}
+
+func (b *memBucket) Bucket(name []byte) Bucket {
+	child, ok := b.buckets[string(name)]
+	if !ok {
+		return nil
+	}
+	return child
+}
+
+func (b *memBucket) CreateBucket(name []byte) (Bucket, error) {
+	if _, ok := b.buckets[string(name)]; ok {
+		return nil, errBucketExists
+	}
+	child := newMemBucket()
+	b.buckets[string(name)] = child
+	return child, nil
+}
+
+func (b *memBucket) DeleteBucket(name []byte) error {
+	delete(b.buckets, string(name))
+	return nil
+}
+
+func (b *memBucket) ForEach(fn func(k, v []byte) error) error {
+	for _, k := range b.sortedKeys() {
+		if _, ok := b.buckets[k]; ok {
+			if err := fn([]byte(k), nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn([]byte(k), b.values[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memBucket) sortedKeys() []string {
+	keys := make([]string, 0, len(b.buckets)+len(b.values))
+	for k := range b.buckets {
+		keys = append(keys, k)
+	}
+	for k := range b.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (b *memBucket) Put(key, value []byte) error {
+	cp := make([]byte, len(value))
+	copy(cp, value)
+	b.values[string(key)] = cp
+	return nil
+}
+
+func (b *memBucket) Cursor() Cursor {
+	return &memCursor{bucket: b, keys: b.sortedKeys(), pos: -1}
+}
+
+// memCursor snapshots the bucket's sorted keys when created, matching bolt's guarantee that a
+// cursor sees a consistent view for the lifetime of its transaction.
+type memCursor struct {
+	bucket *memBucket
+	keys   []string
+	pos    int
+}
+
+func (c *memCursor) at(pos int) ([]byte, []byte) {
+	if pos < 0 || pos >= len(c.keys) {
+		return nil, nil
+	}
+	c.pos = pos
+	k := c.keys[pos]
+	if _, ok := c.bucket.buckets[k]; ok {
+		return []byte(k), nil
+	}
+	return []byte(k), c.bucket.values[k]
+}
+
+func (c *memCursor) First() ([]byte, []byte) {
+	return c.at(0)
+}
+
+func (c *memCursor) Last() ([]byte, []byte) {
+	return c.at(len(c.keys) - 1)
+}
+
+func (c *memCursor) Next() ([]byte, []byte) {
+	return c.at(c.pos + 1)
+}
+
+func (c *memCursor) Prev() ([]byte, []byte) {
+	return c.at(c.pos - 1)
+}
+
+func (c *memCursor) Seek(seek []byte) ([]byte, []byte) {
+	target := string(seek)
+	idx := sort.SearchStrings(c.keys, target)
+	return c.at(idx)
+}
+
+func (c *memCursor) Delete() error {
+	if c.pos < 0 || c.pos >= len(c.keys) {
+		return nil
+	}
+	k := c.keys[c.pos]
+	delete(c.bucket.buckets, k)
+	delete(c.bucket.values, k)
+	c.keys = append(c.keys[:c.pos], c.keys[c.pos+1:]...)
+	c.pos--
+	return nil
+}
+
+var _ Engine = &memEngine{}
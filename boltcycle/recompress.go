@@ -0,0 +1,56 @@
+package boltcycle
+
+import "context"
+
+// Recompress walks every key in the bucket cycle and rewrites its value under the currently
+// configured ValueCodec.  Values carrying the configured codec's ID are decoded first; every other
+// value is treated as raw bytes, and with no codec configured values are written back unchanged.
+// This is useful
+// when flipping compression on for an existing database: new writes are encoded as soon as
+// WithValueCodec is configured, but values written earlier stay in whatever format they already
+// had until something like Recompress rewrites them.  ctx is checked between buckets and between
+// keys so a long recompress can be cancelled.
+func (c *CycleDB) Recompress(ctx context.Context) error {
+	return c.engine.Update(func(tx Tx) error {
+		bucket := tx.Bucket(c.timesInBucketName())
+		if bucket == nil {
+			return errUnableToFindRootBucket
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return errUnexpectedNonBucket
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return c.recompressBucket(ctx, bucket.Bucket(k))
+		})
+	})
+}
+
+func (c *CycleDB) recompressBucket(ctx context.Context, timeBucket Bucket) error {
+	// Put while a Cursor from the same bucket is mid-traversal is undefined behavior for bolt-
+	// style engines, so read every pair into memory first and only then write them back.
+	var pairs []KvPair
+	cursor := timeBucket.Cursor()
+	for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		decoded, err := c.decodeValue(value)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, KvPair{Key: append([]byte{}, key...), Value: c.encodeValue(decoded)})
+	}
+
+	for _, pair := range pairs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := timeBucket.Put(pair.Key, pair.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
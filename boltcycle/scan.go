@@ -0,0 +1,133 @@
+package boltcycle
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+)
+
+// Scan calls fn for every key in [start, end) across the whole bucket cycle, in ascending key
+// order, merging all per-time buckets with a heap of cursors (scanHeap) like the cursorHeap
+// VerifyCompressed uses.
+// Unlike VerifyCompressed, which only checks for duplicates, Scan resolves them: when the same key
+// exists in more than one bucket, the value from the newest bucket (the largest uint64 bucket
+// name) wins and the rest are skipped, so fn sees one logical value per key. Values pass through
+// decodeValue first, so fn sees the same decoded bytes Read would return. A nil start scans from
+// the beginning; a nil end scans to the end.
+func (c *CycleDB) Scan(start, end []byte, fn func(k, v []byte) error) error {
+	return c.engine.View(func(tx Tx) error {
+		bucket := tx.Bucket(c.timesInBucketName())
+		if bucket == nil {
+			return errUnableToFindRootBucket
+		}
+
+		sh, err := newScanHeap(bucket, start)
+		if err != nil {
+			return err
+		}
+		heap.Init(&sh)
+
+		for len(sh) > 0 {
+			key, value := sh[0].key, sh[0].value
+			if end != nil && bytes.Compare(key, end) >= 0 {
+				return nil
+			}
+			decoded, err := c.decodeValue(value)
+			if err != nil {
+				return err
+			}
+			if err := fn(key, decoded); err != nil {
+				return err
+			}
+			for len(sh) > 0 && bytes.Equal(sh[0].key, key) {
+				advanceScanEntry(&sh)
+			}
+		}
+		return nil
+	})
+}
+
+// scanEntry is the head of one per-time-bucket cursor as Scan merges them.
+type scanEntry struct {
+	cursor      Cursor
+	bucketIndex uint64
+	key, value  []byte
+}
+
+// scanHeap orders scanEntry by key, breaking ties in favor of the largest bucketIndex so the
+// newest copy of a duplicated key always surfaces first.
+type scanHeap []scanEntry
+
+func (h scanHeap) Len() int { return len(h) }
+
+func (h scanHeap) Less(i, j int) bool {
+	switch bytes.Compare(h[i].key, h[j].key) {
+	case -1:
+		return true
+	case 1:
+		return false
+	default:
+		return h[i].bucketIndex > h[j].bucketIndex
+	}
+}
+
+func (h scanHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scanHeap) Push(x interface{}) {
+	*h = append(*h, x.(scanEntry))
+}
+
+func (h *scanHeap) Pop() interface{} {
+	n := len(*h)
+	item := (*h)[n-1]
+	*h = (*h)[0 : n-1]
+	return item
+}
+
+var _ heap.Interface = &scanHeap{}
+
+// newScanHeap seeks every per-time bucket's cursor to start (or First, if start is nil) and
+// returns the non-empty ones as a scanHeap ready for heap.Init.
+func newScanHeap(bucket Bucket, start []byte) (scanHeap, error) {
+	var sh scanHeap
+	err := bucket.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return errUnexpectedNonBucket
+		}
+		if len(k) != 8 {
+			return errUnexpectedBucketBytes
+		}
+		timeBucket := bucket.Bucket(k)
+		cursor := timeBucket.Cursor()
+		var key, value []byte
+		if start != nil {
+			key, value = cursor.Seek(start)
+		} else {
+			key, value = cursor.First()
+		}
+		if key != nil {
+			sh = append(sh, scanEntry{
+				cursor:      cursor,
+				bucketIndex: binary.BigEndian.Uint64(k),
+				key:         key,
+				value:       value,
+			})
+		}
+		return nil
+	})
+	return sh, err
+}
+
+// advanceScanEntry moves the top entry of the heap to its cursor's next key, dropping it from the
+// heap if its bucket is exhausted.
+func advanceScanEntry(sh *scanHeap) {
+	top := (*sh)[0]
+	key, value := top.cursor.Next()
+	if key == nil {
+		heap.Pop(sh)
+		return
+	}
+	top.key, top.value = key, value
+	(*sh)[0] = top
+	heap.Fix(sh, 0)
+}
@@ -0,0 +1,27 @@
+package boltcycle
+
+import "github.com/golang/snappy"
+
+// snappyCodecID is SnappyCodec's one byte format prefix.
+const snappyCodecID byte = 1
+
+// SnappyCodec is a ValueCodec using snappy, the compression library already ubiquitous in the
+// bolt/etcd/raft-boltdb ecosystem.
+type SnappyCodec struct{}
+
+// ID implements ValueCodec.
+func (SnappyCodec) ID() byte {
+	return snappyCodecID
+}
+
+// Encode implements ValueCodec.
+func (SnappyCodec) Encode(value []byte) []byte {
+	return snappy.Encode(nil, value)
+}
+
+// Decode implements ValueCodec.
+func (SnappyCodec) Decode(value []byte) ([]byte, error) {
+	return snappy.Decode(nil, value)
+}
+
+var _ ValueCodec = SnappyCodec{}
@@ -0,0 +1,60 @@
+package boltcycle
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Snapshot streams every key/value pair in the database to w as a sequence of
+// (bucketIndex uint64, key []byte, value []byte) tuples, oldest bucket first.  Values pass through
+// decodeValue first, so the tuples carry the same decoded bytes Read would return, regardless of
+// the ValueCodec in effect when they were written.  Unlike Backup, which is a file-level copy tied
+// to bbolt, Snapshot works against any Engine and can be read back by a replica running a different
+// backend entirely.
+//
+// Each tuple is encoded as an 8 byte big endian bucketIndex, a 4 byte big endian key length, a 4
+// byte big endian value length, the key, and the value.
+func (c *CycleDB) Snapshot(w io.Writer) error {
+	return c.engine.View(func(tx Tx) error {
+		bucket := tx.Bucket(c.timesInBucketName())
+		if bucket == nil {
+			return errUnableToFindRootBucket
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return errUnexpectedNonBucket
+			}
+			if len(k) != 8 {
+				return errUnexpectedBucketBytes
+			}
+			bucketIndex := binary.BigEndian.Uint64(k)
+			timeBucket := bucket.Bucket(k)
+			cursor := timeBucket.Cursor()
+			for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+				decoded, err := c.decodeValue(value)
+				if err != nil {
+					return err
+				}
+				if err := writeSnapshotTuple(w, bucketIndex, key, decoded); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+func writeSnapshotTuple(w io.Writer, bucketIndex uint64, key, value []byte) error {
+	var header [16]byte
+	binary.BigEndian.PutUint64(header[0:8], bucketIndex)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(key)))
+	binary.BigEndian.PutUint32(header[12:16], uint32(len(value)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(key); err != nil {
+		return err
+	}
+	_, err := w.Write(value)
+	return err
+}